@@ -0,0 +1,233 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// cacheEntry is what's persisted per cache key under ~/.cache/git-aicommit/.
+type cacheEntry struct {
+	Provider  string    `json:"provider"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// cacheDir returns ~/.cache/git-aicommit, creating it if necessary.
+func cacheDir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(base, "git-aicommit")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// cacheKey hashes the provider, model params and normalized prompt
+// messages (system prompt + diff) into a single content-addressable key,
+// so the same diff/prompt/provider combination always replays the same
+// cached message.
+func cacheKey(provider, model string, temperature *float32, messages []ChatMessage) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "provider=%s\nmodel=%s\n", provider, model)
+	if temperature != nil {
+		fmt.Fprintf(h, "temperature=%v\n", *temperature)
+	}
+	for _, m := range messages {
+		fmt.Fprintf(h, "%s:%s\n", m.Role, normalizeForCacheKey(m.Content))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// normalizeForCacheKey trims incidental whitespace that shouldn't affect
+// cache hits (trailing spaces per line, leading/trailing blank lines).
+func normalizeForCacheKey(content string) string {
+	lines := strings.Split(content, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func cachePath(key string) (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, key+".json"), nil
+}
+
+// cacheGet returns the cached message for key, if any.
+func cacheGet(key string) (string, bool) {
+	path, err := cachePath(key)
+	if err != nil {
+		return "", false
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false
+	}
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return "", false
+	}
+	return entry.Message, true
+}
+
+// cacheSet stores message under key.
+func cacheSet(key, provider, message string) error {
+	path, err := cachePath(key)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cacheEntry{
+		Provider:  provider,
+		Message:   message,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// runStreamCached wraps runStream with the on-disk cache: a hit replays
+// the stored message (printed line by line in runStream's format) without
+// touching the network; a miss generates normally and, unless noCache is
+// set, stores the result for next time.
+func runStreamCached(provider LLMProvider, messages []ChatMessage, temperature *float32, providerName, modelName string, noCache, cacheOnly bool) (string, error) {
+	key := cacheKey(providerName, modelName, temperature, messages)
+
+	if !noCache {
+		if cached, ok := cacheGet(key); ok {
+			fmt.Println("(replaying cached response, no API call made)")
+			return replayCached(cached), nil
+		}
+	}
+
+	if cacheOnly {
+		return "", fmt.Errorf("no cached response for this diff/provider/prompt, and --cache-only was set")
+	}
+
+	message, err := runStream(provider, messages, temperature)
+	if err != nil {
+		return "", err
+	}
+
+	if !noCache {
+		if err := cacheSet(key, providerName, message); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: failed to write cache: %v\n", err)
+		}
+	}
+
+	return message, nil
+}
+
+// replayCached re-prints a previously generated message line by line in
+// the same "| line" format runStream uses while streaming, and returns it
+// unchanged.
+func replayCached(message string) string {
+	var currentLine strings.Builder
+
+	for _, ch := range message {
+		currentLine.WriteRune(ch)
+		if ch == '\n' {
+			fmt.Printf("| %s", strings.TrimSuffix(currentLine.String(), "\n"))
+			fmt.Println()
+			currentLine.Reset()
+		}
+	}
+	if currentLine.Len() > 0 {
+		fmt.Printf("| %s\n", strings.TrimSpace(currentLine.String()))
+	}
+
+	return message
+}
+
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Inspect or manage the local response cache",
+}
+
+var cachePruneOlderThan string
+
+var cachePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove cached responses older than a given age",
+	RunE:  runCachePrune,
+}
+
+func init() {
+	cachePruneCmd.Flags().StringVar(&cachePruneOlderThan, "older-than", "30d", "Remove cache entries older than this (e.g. 24h, 30d)")
+	cacheCmd.AddCommand(cachePruneCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+func runCachePrune(cmd *cobra.Command, args []string) error {
+	maxAge, err := parseCacheAge(cachePruneOlderThan)
+	if err != nil {
+		return err
+	}
+
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			if err := os.Remove(path); err == nil {
+				removed++
+			}
+		}
+	}
+
+	fmt.Printf("Removed %d cached response(s) older than %s.\n", removed, cachePruneOlderThan)
+	return nil
+}
+
+// parseCacheAge parses durations like "30d" (days, which time.ParseDuration
+// doesn't support) in addition to anything time.ParseDuration accepts.
+func parseCacheAge(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil || days < 0 {
+			return 0, fmt.Errorf("invalid duration %q: must be a non-negative number of days", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("invalid duration %q: must be non-negative", s)
+	}
+	return d, nil
+}
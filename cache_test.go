@@ -0,0 +1,95 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheKeyStableForSameInput(t *testing.T) {
+	messages := []ChatMessage{{Role: "system", Content: "prompt"}, {Role: "user", Content: "diff"}}
+	temp := float32(0.2)
+
+	a := cacheKey("deepseek", "deepseek-chat", &temp, messages)
+	b := cacheKey("deepseek", "deepseek-chat", &temp, messages)
+	if a != b {
+		t.Fatalf("expected identical key for identical input, got %q vs %q", a, b)
+	}
+}
+
+func TestCacheKeyDiffersByProviderModelTemperatureAndContent(t *testing.T) {
+	messages := []ChatMessage{{Role: "user", Content: "diff"}}
+	temp := float32(0.2)
+	otherTemp := float32(0.5)
+	base := cacheKey("deepseek", "deepseek-chat", &temp, messages)
+
+	cases := map[string]string{
+		"provider":    cacheKey("openai", "deepseek-chat", &temp, messages),
+		"model":       cacheKey("deepseek", "gpt-4o-mini", &temp, messages),
+		"temperature": cacheKey("deepseek", "deepseek-chat", &otherTemp, messages),
+		"nil temp":    cacheKey("deepseek", "deepseek-chat", nil, messages),
+		"content":     cacheKey("deepseek", "deepseek-chat", &temp, []ChatMessage{{Role: "user", Content: "other diff"}}),
+	}
+	for name, got := range cases {
+		if got == base {
+			t.Errorf("expected key to change when %s differs, but it matched the base key", name)
+		}
+	}
+}
+
+func TestNormalizeForCacheKeyIgnoresIncidentalWhitespace(t *testing.T) {
+	a := normalizeForCacheKey("line one  \nline two\t\n")
+	b := normalizeForCacheKey("\nline one\nline two\n\n")
+	if a != b {
+		t.Fatalf("expected whitespace-only differences to normalize equal, got %q vs %q", a, b)
+	}
+}
+
+func TestParseCacheAgeDays(t *testing.T) {
+	d, err := parseCacheAge("30d")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d != 30*24*time.Hour {
+		t.Fatalf("expected 30 days, got %v", d)
+	}
+}
+
+func TestParseCacheAgeDuration(t *testing.T) {
+	d, err := parseCacheAge("24h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if d != 24*time.Hour {
+		t.Fatalf("expected 24h, got %v", d)
+	}
+}
+
+func TestParseCacheAgeRejectsNegativeDays(t *testing.T) {
+	if _, err := parseCacheAge("-1d"); err == nil {
+		t.Fatal("expected an error for a negative day count, got nil")
+	}
+}
+
+func TestParseCacheAgeRejectsNegativeDuration(t *testing.T) {
+	if _, err := parseCacheAge("-1h"); err == nil {
+		t.Fatal("expected an error for a negative duration, got nil")
+	}
+}
+
+func TestParseCacheAgeRejectsGarbage(t *testing.T) {
+	if _, err := parseCacheAge("not-a-duration"); err == nil {
+		t.Fatal("expected an error for an unparseable duration, got nil")
+	}
+}
+
+func TestReplayCachedReturnsMessageUnchanged(t *testing.T) {
+	for _, message := range []string{
+		"feat(api): add pagination\n\n- page and page_size params",
+		"single line, no trailing newline",
+		"",
+	} {
+		if got := replayCached(message); got != message {
+			t.Fatalf("expected replayCached to return its input unchanged, got %q for input %q", got, message)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// conventionalTypes are the commit types allowed by --conventional, per the
+// Conventional Commits spec (https://www.conventionalcommits.org/).
+var conventionalTypes = []string{
+	"feat", "fix", "chore", "docs", "refactor", "test", "style", "build", "ci", "perf", "revert",
+}
+
+// maxConventionalRetries bounds how many times we ask the model to repair
+// a message that fails local validation before giving up and returning the
+// last attempt as-is.
+const maxConventionalRetries = 3
+
+// conventionalHeaderPattern matches "<type>(<scope>): <subject>" or
+// "<type>: <subject>", capturing the type, optional scope, and subject.
+var conventionalHeaderPattern = regexp.MustCompile(`^([a-z]+)(\(([^)]+)\))?(!)?: (.+)$`)
+
+const maxSubjectLength = 72
+
+// buildConventionalPrompt returns the system prompt used in --conventional
+// mode, constraining the model to the Conventional Commits format and the
+// allowed type list, with any configured scopes injected as guidance.
+func buildConventionalPrompt(scopes []string) string {
+	var b strings.Builder
+	b.WriteString("You are an AI commit message assistant.\n\n")
+	b.WriteString("Generate a commit message that strictly follows the Conventional Commits format:\n\n")
+	b.WriteString("<type>(<scope>): <subject>\n\n<body>\n\n<footer>\n\n")
+	fmt.Fprintf(&b, "The <type> must be one of: %s.\n", strings.Join(conventionalTypes, ", "))
+	b.WriteString("The <scope> is optional but recommended when the change is localized.\n")
+	if len(scopes) > 0 {
+		fmt.Fprintf(&b, "Prefer one of these scopes when applicable: %s.\n", strings.Join(scopes, ", "))
+	}
+	b.WriteString("The subject line (type, scope and subject combined) must be 72 characters or fewer.\n")
+	b.WriteString("The body is a bullet-point list of changes (start each line with \"- \").\n")
+	b.WriteString("If the change breaks backward compatibility, add a \"!\" right before the \":\" in the header " +
+		"(e.g. \"feat(api)!: ...\") AND add a footer starting with \"BREAKING CHANGE: \" describing the break.\n")
+	b.WriteString("\nExample:\n\nfeat(api): add pagination to the search endpoint\n\n- Add page and page_size query parameters\n- Return a total_count field in the response body\n")
+	return b.String()
+}
+
+// validateConventional checks message against the Conventional Commits
+// rules and returns a human-readable violation for each rule broken. An
+// empty slice means the message is valid.
+func validateConventional(message string, scopes []string) []string {
+	var violations []string
+
+	lines := strings.SplitN(strings.TrimSpace(message), "\n", 2)
+	header := lines[0]
+
+	match := conventionalHeaderPattern.FindStringSubmatch(header)
+	if match == nil {
+		violations = append(violations, fmt.Sprintf("header %q does not match \"<type>(<scope>): <subject>\"", header))
+		return violations
+	}
+
+	commitType := match[1]
+	if !containsString(conventionalTypes, commitType) {
+		violations = append(violations, fmt.Sprintf("type %q is not one of: %s", commitType, strings.Join(conventionalTypes, ", ")))
+	}
+
+	if scope := match[3]; scope != "" && len(scopes) > 0 && !containsString(scopes, scope) {
+		violations = append(violations, fmt.Sprintf("scope %q is not one of the configured scopes: %s", scope, strings.Join(scopes, ", ")))
+	}
+
+	if len(header) > maxSubjectLength {
+		violations = append(violations, fmt.Sprintf("header is %d characters, exceeds the %d character limit", len(header), maxSubjectLength))
+	}
+
+	var body string
+	if len(lines) > 1 {
+		body = lines[1]
+	}
+	hasBreakingMarker := match[4] == "!"
+	hasBreakingFooter := strings.Contains(body, "BREAKING CHANGE:")
+
+	if hasBreakingMarker && !hasBreakingFooter {
+		violations = append(violations, "header has a \"!\" breaking-change marker but the body has no \"BREAKING CHANGE:\" footer")
+	}
+	if hasBreakingFooter && !hasBreakingMarker {
+		violations = append(violations, "body has a \"BREAKING CHANGE:\" footer but the header is missing the \"!\" breaking-change marker")
+	}
+
+	return violations
+}
+
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
+
+// ptrTo returns a pointer to v, for passing literals where *string is
+// expected (e.g. a config prompt override).
+func ptrTo[T any](v T) *T {
+	return &v
+}
+
+// enforceConventional validates message against the Conventional Commits
+// rules and, on failure, retries generation with a repair prompt up to
+// maxConventionalRetries times. It returns the last generated message even
+// if it never fully validates, so the caller always gets something to
+// review.
+func enforceConventional(provider LLMProvider, messages []ChatMessage, temperature *float32, message string, scopes []string) (string, error) {
+	message = strings.TrimSpace(message)
+
+	for attempt := 0; attempt < maxConventionalRetries; attempt++ {
+		violations := validateConventional(message, scopes)
+		if len(violations) == 0 {
+			return message, nil
+		}
+
+		repairMessages := append(append([]ChatMessage{}, messages...), ChatMessage{
+			Role:    "user",
+			Content: repairPrompt(message, violations),
+		})
+
+		fmt.Printf("\nCommit message failed validation, retrying (%d/%d)...\n", attempt+1, maxConventionalRetries)
+		regenerated, err := runStream(provider, repairMessages, temperature)
+		if err != nil {
+			return message, err
+		}
+		message = strings.TrimSpace(regenerated)
+	}
+
+	return message, nil
+}
+
+// repairPrompt builds the user message sent back to the model when a
+// generated commit message fails validation, asking it to fix the listed
+// violations.
+func repairPrompt(message string, violations []string) string {
+	var b strings.Builder
+	b.WriteString("The commit message you generated does not follow the required format:\n\n")
+	b.WriteString(message)
+	b.WriteString("\n\nViolations:\n")
+	for _, v := range violations {
+		fmt.Fprintf(&b, "- %s\n", v)
+	}
+	b.WriteString("\nPlease regenerate the full commit message, fixing these violations.")
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package main
+
+import "testing"
+
+func TestValidateConventionalValidMessage(t *testing.T) {
+	violations := validateConventional("feat(api): add pagination to search", []string{"api", "cli"})
+	if len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+}
+
+func TestValidateConventionalBadHeader(t *testing.T) {
+	violations := validateConventional("added pagination", nil)
+	if len(violations) != 1 {
+		t.Fatalf("expected exactly one violation, got %v", violations)
+	}
+}
+
+func TestValidateConventionalUnknownType(t *testing.T) {
+	violations := validateConventional("oops(api): add pagination", nil)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation for unknown type, got %v", violations)
+	}
+}
+
+func TestValidateConventionalUnknownScope(t *testing.T) {
+	violations := validateConventional("feat(db): add pagination", []string{"api", "cli"})
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation for unconfigured scope, got %v", violations)
+	}
+}
+
+func TestValidateConventionalSubjectTooLong(t *testing.T) {
+	long := "feat(api): this subject line is deliberately far too long to fit in seventy two characters"
+	violations := validateConventional(long, nil)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation for overlong subject, got %v", violations)
+	}
+}
+
+func TestValidateConventionalBreakingMarkerWithoutFooter(t *testing.T) {
+	violations := validateConventional("feat(api)!: remove old endpoint\n\n- drop v1 route", nil)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation for missing BREAKING CHANGE footer, got %v", violations)
+	}
+}
+
+func TestValidateConventionalFooterWithoutMarker(t *testing.T) {
+	msg := "feat(api): remove old endpoint\n\n- drop v1 route\n\nBREAKING CHANGE: v1 route removed"
+	violations := validateConventional(msg, nil)
+	if len(violations) != 1 {
+		t.Fatalf("expected one violation for missing \"!\" marker, got %v", violations)
+	}
+}
+
+func TestValidateConventionalBreakingMarkerWithFooter(t *testing.T) {
+	msg := "feat(api)!: remove old endpoint\n\n- drop v1 route\n\nBREAKING CHANGE: v1 route removed"
+	violations := validateConventional(msg, nil)
+	if len(violations) != 0 {
+		t.Fatalf("expected no violations, got %v", violations)
+	}
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultMaxDiffBytes and defaultSummarizeThresholdBytes bound the size of
+// the diff we're willing to send to the model. Token counting uses a
+// simple bytes/4 heuristic, so these are expressed in bytes.
+const (
+	defaultMaxDiffBytes            = 24000 // ~6000 tokens
+	defaultSummarizeThresholdBytes = 16000 // ~4000 tokens
+
+	// maxFileDiffForSummary caps how much of a single file's diff is sent
+	// to the model during the per-file map step.
+	maxFileDiffForSummary = 8000
+)
+
+type fileDiff struct {
+	Path    string
+	Content string
+}
+
+// runGitDiff runs `git diff` with baseArgs (which selects what's being
+// diffed - e.g. "--cached", a commit range, or nothing for the worktree)
+// followed by extra arguments, and returns its output.
+func runGitDiff(baseArgs []string, extra ...string) (string, error) {
+	args := append(append([]string{}, baseArgs...), extra...)
+	cmd := exec.Command("git", append([]string{"diff"}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+// getDiff returns the full diff selected by baseArgs.
+func getDiff(baseArgs []string) (string, error) {
+	output, err := runGitDiff(baseArgs)
+	if err != nil {
+		return "", err
+	}
+	if len(output) == 0 {
+		return "", fmt.Errorf("no changes found")
+	}
+	return output, nil
+}
+
+// effectiveMaxDiffBytes resolves the diff byte budget from (in priority
+// order) the --max-diff-bytes flag, the max_diff_tokens config key, or the
+// built-in default.
+func effectiveMaxDiffBytes(flagBytes, configTokens int) int {
+	if flagBytes > 0 {
+		return flagBytes
+	}
+	if configTokens > 0 {
+		return configTokens * 4
+	}
+	return defaultMaxDiffBytes
+}
+
+// effectiveSummarizeThreshold resolves the byte size above which the diff
+// gets map-reduced into per-file summaries instead of sent in full.
+func effectiveSummarizeThreshold(flagBytes int) int {
+	if flagBytes > 0 {
+		return flagBytes
+	}
+	return defaultSummarizeThresholdBytes
+}
+
+// prepareDiffContext builds the text that gets embedded in the commit
+// message prompt: a file overview (stat + name-status), and either the
+// full diff selected by baseArgs or, once it exceeds summarizeThreshold
+// bytes or the maxDiffBytes budget, a map-reduced summary produced by
+// calling provider once per changed file.
+func prepareDiffContext(provider LLMProvider, temperature *float32, maxDiffBytes, summarizeThreshold int, baseArgs []string) (string, error) {
+	stat, err := runGitDiff(baseArgs, "--stat")
+	if err != nil {
+		return "", err
+	}
+	nameStatus, err := runGitDiff(baseArgs, "--name-status")
+	if err != nil {
+		return "", err
+	}
+
+	fullDiff, err := getDiff(baseArgs)
+	if err != nil {
+		return "", err
+	}
+
+	var overview strings.Builder
+	overview.WriteString("Files changed (stat):\n")
+	overview.WriteString(stat)
+	overview.WriteString("\nFiles changed (status):\n")
+	overview.WriteString(nameStatus)
+
+	if len(fullDiff) <= summarizeThreshold && len(fullDiff) <= maxDiffBytes {
+		overview.WriteString("\nFull diff:\n")
+		overview.WriteString(fullDiff)
+		return overview.String(), nil
+	}
+
+	fmt.Printf("Diff is ~%d tokens, summarizing per-file to fit the prompt budget...\n", estimateTokens(fullDiff))
+
+	summarized, err := summarizeDiff(provider, temperature, splitDiffByFile(fullDiff), maxDiffBytes)
+	if err != nil {
+		return "", err
+	}
+	overview.WriteString("\n")
+	overview.WriteString(summarized)
+	return overview.String(), nil
+}
+
+// diffFileHeaderPattern matches the "diff --git a/<path> b/<path>" line
+// that starts each file's section in a unified diff.
+var diffFileHeaderPattern = regexp.MustCompile(`^diff --git a/.+ b/(.+)$`)
+
+// splitDiffByFile splits a full `git diff` into one fileDiff per changed
+// file.
+func splitDiffByFile(diff string) []fileDiff {
+	var files []fileDiff
+	var current *fileDiff
+
+	for _, line := range strings.Split(diff, "\n") {
+		if m := diffFileHeaderPattern.FindStringSubmatch(line); m != nil {
+			if current != nil {
+				files = append(files, *current)
+			}
+			current = &fileDiff{Path: m[1]}
+		}
+		if current != nil {
+			current.Content += line + "\n"
+		}
+	}
+	if current != nil {
+		files = append(files, *current)
+	}
+	return files
+}
+
+// summarizeDiff implements the map-reduce step for oversized diffs: it
+// asks the model for a short per-file summary (the map step), then
+// reduces those summaries plus the full diff of the smallest files - as
+// much as fits within maxDiffBytes - into the text sent to the final
+// commit-message prompt.
+func summarizeDiff(provider LLMProvider, temperature *float32, files []fileDiff, maxDiffBytes int) (string, error) {
+	summaries := make([]string, 0, len(files))
+	for _, f := range files {
+		summary, err := summarizeFileDiff(provider, temperature, f)
+		if err != nil {
+			return "", err
+		}
+		summaries = append(summaries, fmt.Sprintf("%s:\n%s", f.Path, summary))
+	}
+
+	var out strings.Builder
+	out.WriteString("Per-file summary (the full diff was too large, so it was summarized):\n\n")
+	out.WriteString(strings.Join(summaries, "\n\n"))
+
+	smallest := append([]fileDiff{}, files...)
+	sort.Slice(smallest, func(i, j int) bool { return len(smallest[i].Content) < len(smallest[j].Content) })
+
+	const detailHeader = "\n\nFull diff for the smallest changed files:\n\n"
+	budget := maxDiffBytes - out.Len() - len(detailHeader)
+	var detail strings.Builder
+	for _, f := range smallest {
+		if budget <= 0 || len(f.Content) > budget {
+			continue
+		}
+		detail.WriteString(f.Content)
+		budget -= len(f.Content)
+	}
+	if detail.Len() > 0 {
+		out.WriteString(detailHeader)
+		out.WriteString(detail.String())
+	}
+
+	return out.String(), nil
+}
+
+// summarizeFileDiff asks the model for a one-to-two bullet summary of a
+// single file's diff (the map step of summarizeDiff).
+func summarizeFileDiff(provider LLMProvider, temperature *float32, f fileDiff) (string, error) {
+	content := f.Content
+	if len(content) > maxFileDiffForSummary {
+		content = content[:maxFileDiffForSummary] + "\n... (truncated)"
+	}
+
+	messages := []ChatMessage{
+		{
+			Role:    "system",
+			Content: "You summarize a single file's git diff in 1-2 short bullet points (start each with \"- \"). Reply with only the bullets, no preamble.",
+		},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("File: %s\n\n%s", f.Path, content),
+		},
+	}
+
+	return collectCompletion(provider, messages, temperature)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestSplitDiffByFile(t *testing.T) {
+	diff := "diff --git a/foo.go b/foo.go\n" +
+		"index 111..222 100644\n" +
+		"--- a/foo.go\n" +
+		"+++ b/foo.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-old\n" +
+		"+new\n" +
+		"diff --git a/bar.go b/bar.go\n" +
+		"--- a/bar.go\n" +
+		"+++ b/bar.go\n" +
+		"@@ -1 +1 @@\n" +
+		"-x\n" +
+		"+y\n"
+
+	files := splitDiffByFile(diff)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 files, got %d", len(files))
+	}
+	if files[0].Path != "foo.go" || files[1].Path != "bar.go" {
+		t.Fatalf("unexpected paths: %+v", files)
+	}
+	if !strings.Contains(files[0].Content, "-old") || strings.Contains(files[0].Content, "-x") {
+		t.Fatalf("file content not split correctly: %q", files[0].Content)
+	}
+}
+
+// fakeProvider is a stub LLMProvider for tests that don't want to hit a
+// real backend: it streams back a fixed reply regardless of the prompt.
+type fakeProvider struct {
+	reply string
+}
+
+func (p *fakeProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	out := make(chan string, 1)
+	out <- p.reply
+	close(out)
+	return out, nil
+}
+
+func TestSummarizeDiffIncludesSmallestFullDiffsWithinBudget(t *testing.T) {
+	files := []fileDiff{
+		{Path: "big.go", Content: strings.Repeat("x", 1000)},
+		{Path: "small.go", Content: strings.Repeat("y", 10)},
+	}
+
+	out, err := summarizeDiff(&fakeProvider{reply: "- did a thing"}, nil, files, 200)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(out, "small.go:") {
+		t.Fatalf("expected per-file summary for small.go, got: %s", out)
+	}
+	if !strings.Contains(out, strings.Repeat("y", 10)) {
+		t.Fatalf("expected full diff of the smallest file within budget, got: %s", out)
+	}
+	if strings.Contains(out, strings.Repeat("x", 1000)) {
+		t.Fatalf("did not expect the oversized file's full diff to be included: %s", out)
+	}
+}
+
+func TestEffectiveMaxDiffBytes(t *testing.T) {
+	if got := effectiveMaxDiffBytes(500, 0); got != 500 {
+		t.Fatalf("flag should win, got %d", got)
+	}
+	if got := effectiveMaxDiffBytes(0, 100); got != 400 {
+		t.Fatalf("config tokens*4 expected 400, got %d", got)
+	}
+	if got := effectiveMaxDiffBytes(0, 0); got != defaultMaxDiffBytes {
+		t.Fatalf("expected default %d, got %d", defaultMaxDiffBytes, got)
+	}
+}
+
+func TestEffectiveSummarizeThreshold(t *testing.T) {
+	if got := effectiveSummarizeThreshold(123); got != 123 {
+		t.Fatalf("flag should win, got %d", got)
+	}
+	if got := effectiveSummarizeThreshold(0); got != defaultSummarizeThresholdBytes {
+		t.Fatalf("expected default %d, got %d", defaultSummarizeThresholdBytes, got)
+	}
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// resolveDiffMode picks exactly one diff source from the mutually
+// exclusive --unstaged/--all/--amend/--range flags, defaulting to
+// "staged" (the original, pre-existing behavior) when none are set.
+func resolveDiffMode(unstaged, all, amend bool, rangeSpec string) (string, error) {
+	selected := 0
+	mode := "staged"
+
+	if unstaged {
+		selected++
+		mode = "unstaged"
+	}
+	if all {
+		selected++
+		mode = "all"
+	}
+	if amend {
+		selected++
+		mode = "amend"
+	}
+	if rangeSpec != "" {
+		selected++
+		mode = "range"
+	}
+
+	if selected > 1 {
+		return "", fmt.Errorf("only one of --unstaged, --all, --amend, --range may be given")
+	}
+	return mode, nil
+}
+
+// gitDiffBaseArgs returns the `git diff` arguments that select the diff for
+// mode (everything before any --stat/--name-status/etc.).
+func gitDiffBaseArgs(mode, rangeSpec string) []string {
+	switch mode {
+	case "unstaged":
+		return nil
+	case "amend":
+		return []string{"HEAD~1", "HEAD"}
+	case "range":
+		return []string{rangeSpec}
+	default: // "staged", "all"
+		return []string{"--cached"}
+	}
+}
+
+// stageTrackedChanges stages modifications to already-tracked files (but
+// not new untracked files), mirroring the --all flag's "auto-add" behavior.
+func stageTrackedChanges() error {
+	return exec.Command("git", "add", "-u").Run()
+}
+
+// amendCommit rewrites the last commit's message in place.
+func amendCommit(message string) error {
+	cmd := exec.Command("git", "commit", "--amend", "-m", message)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// getRangeCommitSubjects returns the subject line of every commit in
+// rangeSpec (oldest first), for building a PR changelog.
+func getRangeCommitSubjects(rangeSpec string) ([]string, error) {
+	cmd := exec.Command("git", "log", "--reverse", "--pretty=format:%s", rangeSpec)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	if len(output) == 0 {
+		return nil, nil
+	}
+	return strings.Split(string(output), "\n"), nil
+}
+
+// buildChangelog groups commit subjects by their Conventional Commit type
+// (falling back to "other" for subjects that don't match) into a markdown
+// changelog suitable for a PR description.
+func buildChangelog(subjects []string) string {
+	groups := map[string][]string{}
+	var order []string
+
+	for _, subject := range subjects {
+		commitType := "other"
+		text := subject
+		if m := conventionalHeaderPattern.FindStringSubmatch(subject); m != nil {
+			commitType = m[1]
+			text = m[5]
+		}
+		if _, ok := groups[commitType]; !ok {
+			order = append(order, commitType)
+		}
+		groups[commitType] = append(groups[commitType], text)
+	}
+
+	var b strings.Builder
+	b.WriteString("## Changelog\n")
+	for _, t := range order {
+		fmt.Fprintf(&b, "\n### %s\n", strings.Title(t))
+		for _, text := range groups[t] {
+			fmt.Fprintf(&b, "- %s\n", text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// runPRMode implements `--range <rev1>..<rev2> --output pr`: it builds a
+// changelog from the commits in the range, streams a generated PR title +
+// markdown body to stdout, and - unlike the commit modes - never creates
+// or amends a commit.
+func runPRMode(config *Config, provider LLMProvider, rangeSpec string) error {
+	baseArgs := gitDiffBaseArgs("range", rangeSpec)
+	diffContext, err := prepareDiffContext(
+		provider,
+		config.DeepSeek.Temperature,
+		effectiveMaxDiffBytes(maxDiffBytesFlag, config.MaxDiffTokens),
+		effectiveSummarizeThreshold(summarizeThresholdFlag),
+		baseArgs,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to get diff for range %s: %w", rangeSpec, err)
+	}
+
+	subjects, err := getRangeCommitSubjects(rangeSpec)
+	if err != nil {
+		return fmt.Errorf("failed to list commits in range %s: %w", rangeSpec, err)
+	}
+	changelog := buildChangelog(subjects)
+
+	printBanner("AI Suggested PR Description")
+	if _, err := runStream(provider, buildPRPromptMessages(diffContext, changelog), config.DeepSeek.Temperature); err != nil {
+		return fmt.Errorf("failed to generate PR description: %w", err)
+	}
+	return nil
+}
+
+// buildPRPromptMessages returns the prompt used in --output pr mode: it
+// asks for a longer PR title plus a markdown body, and includes the
+// pre-built changelog so the model doesn't have to re-derive it.
+func buildPRPromptMessages(diffContext, changelog string) []ChatMessage {
+	prompt := `
+You are an AI assistant that writes GitHub pull request descriptions.
+
+Generate a PR description with the following format:
+1. A title line (one sentence, up to 100 characters).
+2. A blank line.
+3. A markdown body summarizing the change for reviewers, ending with the
+   provided changelog section verbatim.
+`
+
+	return []ChatMessage{
+		{Role: "system", Content: prompt},
+		{Role: "user", Content: fmt.Sprintf("Here are the commits in this range:\n%s\n\n%s", diffContext, changelog)},
+	}
+}
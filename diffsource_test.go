@@ -0,0 +1,80 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResolveDiffModeDefaultsToStaged(t *testing.T) {
+	mode, err := resolveDiffMode(false, false, false, "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if mode != "staged" {
+		t.Fatalf("expected staged, got %q", mode)
+	}
+}
+
+func TestResolveDiffModeSelectsEachFlag(t *testing.T) {
+	cases := []struct {
+		name                 string
+		unstaged, all, amend bool
+		rangeSpec, wantMode  string
+	}{
+		{"unstaged", true, false, false, "", "unstaged"},
+		{"all", false, true, false, "", "all"},
+		{"amend", false, false, true, "", "amend"},
+		{"range", false, false, false, "v1..v2", "range"},
+	}
+	for _, c := range cases {
+		mode, err := resolveDiffMode(c.unstaged, c.all, c.amend, c.rangeSpec)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", c.name, err)
+		}
+		if mode != c.wantMode {
+			t.Fatalf("%s: expected mode %q, got %q", c.name, c.wantMode, mode)
+		}
+	}
+}
+
+func TestResolveDiffModeRejectsMultipleFlags(t *testing.T) {
+	if _, err := resolveDiffMode(true, true, false, ""); err == nil {
+		t.Fatal("expected an error when more than one diff source flag is set")
+	}
+}
+
+func TestGitDiffBaseArgs(t *testing.T) {
+	cases := []struct {
+		mode, rangeSpec string
+		want            []string
+	}{
+		{"unstaged", "", nil},
+		{"amend", "", []string{"HEAD~1", "HEAD"}},
+		{"range", "v1..v2", []string{"v1..v2"}},
+		{"staged", "", []string{"--cached"}},
+		{"all", "", []string{"--cached"}},
+	}
+	for _, c := range cases {
+		got := gitDiffBaseArgs(c.mode, c.rangeSpec)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("mode %q: expected %v, got %v", c.mode, c.want, got)
+		}
+	}
+}
+
+func TestBuildChangelogGroupsByConventionalType(t *testing.T) {
+	subjects := []string{
+		"feat(api): add pagination",
+		"fix(cli): handle empty diff",
+		"feat(api): add filters",
+		"tidy up whitespace",
+	}
+	out := buildChangelog(subjects)
+
+	for _, want := range []string{"### Feat", "### Fix", "### Other", "add pagination", "add filters", "handle empty diff", "tidy up whitespace"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected changelog to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// hookMarker identifies a prepare-commit-msg hook as one we installed, so
+// uninstall-hook only removes hooks we own and never a user's own script.
+const hookMarker = "# Installed by `ai-commit install-hook`"
+
+const hookScript = `#!/bin/sh
+` + hookMarker + `
+exec ai-commit --hook "$1" "$2" "$3"
+`
+
+var installHookCmd = &cobra.Command{
+	Use:   "install-hook",
+	Short: "Install ai-commit as a prepare-commit-msg git hook",
+	Long:  `Writes a prepare-commit-msg script into .git/hooks/ so "git commit" itself triggers AI message generation.`,
+	RunE:  runInstallHook,
+}
+
+var uninstallHookCmd = &cobra.Command{
+	Use:   "uninstall-hook",
+	Short: "Remove the ai-commit prepare-commit-msg git hook",
+	RunE:  runUninstallHook,
+}
+
+func init() {
+	rootCmd.AddCommand(installHookCmd, uninstallHookCmd)
+}
+
+func prepareCommitMsgHookPath() (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--git-dir")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("not a git repository: %w", err)
+	}
+	gitDir := strings.TrimSpace(string(output))
+	return filepath.Join(gitDir, "hooks", "prepare-commit-msg"), nil
+}
+
+func runInstallHook(cmd *cobra.Command, args []string) error {
+	hookPath, err := prepareCommitMsgHookPath()
+	if err != nil {
+		return err
+	}
+
+	if existing, err := os.ReadFile(hookPath); err == nil && !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("a prepare-commit-msg hook already exists at %s and was not installed by ai-commit; remove it first", hookPath)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(hookPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(hookPath, []byte(hookScript), 0755); err != nil {
+		return fmt.Errorf("failed to write hook: %w", err)
+	}
+
+	fmt.Printf("Installed prepare-commit-msg hook at %s\n", hookPath)
+	return nil
+}
+
+func runUninstallHook(cmd *cobra.Command, args []string) error {
+	hookPath, err := prepareCommitMsgHookPath()
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No ai-commit hook installed.")
+			return nil
+		}
+		return err
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("the prepare-commit-msg hook at %s was not installed by ai-commit; leaving it in place", hookPath)
+	}
+
+	if err := os.Remove(hookPath); err != nil {
+		return fmt.Errorf("failed to remove hook: %w", err)
+	}
+
+	fmt.Printf("Removed prepare-commit-msg hook at %s\n", hookPath)
+	return nil
+}
+
+// runHookMode implements `ai-commit --hook <commit-msg-file> <source> <sha>`,
+// the prepare-commit-msg entry point: it fills in msgFile with an
+// AI-generated message, unless the user already supplied one or the
+// commit is a merge/squash/reword that already has a message worth
+// keeping.
+func runHookMode(config *Config, msgFile string, args []string) error {
+	var source string
+	if len(args) > 0 {
+		source = args[0]
+	}
+
+	switch source {
+	case "merge", "squash", "commit":
+		return nil
+	}
+
+	existing, err := os.ReadFile(msgFile)
+	if err != nil {
+		return fmt.Errorf("failed to read commit message file: %w", err)
+	}
+	if hasNonEmptyMessage(string(existing)) {
+		return nil
+	}
+
+	// Only build a provider (and require its API key) once we know
+	// generation is actually needed, so a plain `git commit -m "..."`
+	// or a merge/squash never fails because of an unconfigured provider.
+	provider, err := NewProvider(config)
+	if err != nil {
+		return err
+	}
+
+	maxDiffBytes := effectiveMaxDiffBytes(0, config.MaxDiffTokens)
+	summarizeThreshold := effectiveSummarizeThreshold(0)
+	changes, err := prepareDiffContext(provider, config.DeepSeek.Temperature, maxDiffBytes, summarizeThreshold, []string{"--cached"})
+	if err != nil {
+		return fmt.Errorf("failed to get staged changes: %w", err)
+	}
+
+	var messages []ChatMessage
+	if config.Conventional {
+		messages = buildPromptMessages(changes, ptrTo(buildConventionalPrompt(config.Scopes)))
+	} else {
+		messages = buildPromptMessages(changes, config.DeepSeek.Prompt)
+	}
+
+	fullMessage, err := collectCompletion(provider, messages, config.DeepSeek.Temperature)
+	if err != nil {
+		return fmt.Errorf("failed to generate commit message: %w", err)
+	}
+
+	if config.Conventional {
+		fullMessage, err = enforceConventional(provider, messages, config.DeepSeek.Temperature, fullMessage, config.Scopes)
+		if err != nil {
+			return fmt.Errorf("failed to generate a valid Conventional Commits message: %w", err)
+		}
+	}
+
+	// Keep any comment lines git already wrote into the file (e.g. the
+	// "# Please enter the commit message..." template).
+	updated := strings.TrimSpace(fullMessage) + "\n" + string(existing)
+	return os.WriteFile(msgFile, []byte(updated), 0644)
+}
+
+// hasNonEmptyMessage reports whether content has any line that isn't blank
+// or a '#' comment, i.e. whether the user already supplied a message.
+func hasNonEmptyMessage(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		return true
+	}
+	return false
+}
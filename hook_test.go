@@ -0,0 +1,30 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHasNonEmptyMessageDetectsRealContent(t *testing.T) {
+	if !hasNonEmptyMessage("fix(api): handle nil pointer\n# comment\n") {
+		t.Fatal("expected a non-blank, non-comment line to count as a message")
+	}
+}
+
+func TestHasNonEmptyMessageIgnoresBlankAndCommentLines(t *testing.T) {
+	if hasNonEmptyMessage("\n  \n# Please enter the commit message\n#\n") {
+		t.Fatal("expected blank/comment-only content to count as empty")
+	}
+}
+
+func TestHasNonEmptyMessageEmptyString(t *testing.T) {
+	if hasNonEmptyMessage("") {
+		t.Fatal("expected empty string to count as empty")
+	}
+}
+
+func TestHookScriptContainsMarker(t *testing.T) {
+	if !strings.Contains(hookScript, hookMarker) {
+		t.Fatalf("expected hookScript to contain the ownership marker %q, got: %s", hookMarker, hookScript)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// isTerminal reports whether f is attached to an interactive terminal.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// reviewLoop presents the generated commit message to the user and lets
+// them accept it as-is, edit it in $EDITOR, regenerate it (optionally with
+// an extra hint), or quit without committing. It returns the final message
+// to commit and whether the user chose to proceed.
+func reviewLoop(provider LLMProvider, messages []ChatMessage, temperature *float32, message string) (string, bool, error) {
+	reader := bufio.NewReader(os.Stdin)
+
+	for {
+		fmt.Print("\n[a]ccept / [e]dit / [r]egenerate / [q]uit: ")
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return "", false, err
+		}
+
+		switch strings.ToLower(strings.TrimSpace(input)) {
+		case "a", "accept", "":
+			if message == "" {
+				fmt.Println("Empty message, nothing to commit. Aborted.")
+				return "", false, nil
+			}
+			return message, true, nil
+
+		case "e", "edit":
+			edited, err := editMessage(message)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to edit message: %w", err)
+			}
+			if edited == "" {
+				fmt.Println("Empty message. Aborted.")
+				return "", false, nil
+			}
+			message = edited
+			fmt.Println("\nUpdated message:")
+			fmt.Println(message)
+
+		case "r", "regenerate":
+			fmt.Print("Hint for regeneration (optional, press enter to skip): ")
+			hint, _ := reader.ReadString('\n')
+			hint = strings.TrimSpace(hint)
+
+			regenMessages := messages
+			if hint != "" {
+				regenMessages = append(append([]ChatMessage{}, messages...), ChatMessage{
+					Role:    "user",
+					Content: hint,
+				})
+			}
+
+			printBanner("AI Suggested Commit Message")
+			regenerated, err := runStream(provider, regenMessages, temperature)
+			if err != nil {
+				return "", false, fmt.Errorf("failed to regenerate commit message: %w", err)
+			}
+			message = strings.TrimSpace(regenerated)
+
+		case "q", "quit":
+			return "", false, nil
+
+		default:
+			fmt.Println("Please enter a, e, r, or q.")
+		}
+	}
+}
+
+// editMessage opens $EDITOR on a temp file pre-populated with message plus
+// commented-out guidance, waits for the editor to exit, and returns the
+// file's contents with comment lines stripped.
+func editMessage(message string) (string, error) {
+	tmpFile, err := os.CreateTemp("", "ai-commit-*.txt")
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
+	defer os.Remove(tmpPath)
+
+	guidance := "\n# Edit the commit message above as needed, then save and close the file.\n" +
+		"# Lines starting with '#' are comments and will be stripped.\n" +
+		"# An empty message aborts the commit.\n"
+
+	if _, err := tmpFile.WriteString(message + guidance); err != nil {
+		tmpFile.Close()
+		return "", err
+	}
+	if err := tmpFile.Close(); err != nil {
+		return "", err
+	}
+
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+	editorArgs := strings.Fields(editor)
+	if len(editorArgs) == 0 {
+		editorArgs = []string{"vi"}
+	}
+
+	cmd := exec.Command(editorArgs[0], append(editorArgs[1:], tmpPath)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+
+	edited, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return "", err
+	}
+
+	return stripCommentLines(string(edited)), nil
+}
+
+// stripCommentLines removes '#'-prefixed guidance lines and trims
+// surrounding whitespace, leaving only the actual commit message.
+func stripCommentLines(content string) string {
+	var kept []string
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestStripCommentLinesRemovesCommentsAndTrims(t *testing.T) {
+	content := "\nfeat(api): add pagination\n\n- page and page_size params\n" +
+		"# Edit the commit message above as needed, then save and close the file.\n" +
+		"# Lines starting with '#' are comments and will be stripped.\n" +
+		"# An empty message aborts the commit.\n"
+
+	got := stripCommentLines(content)
+	want := "feat(api): add pagination\n\n- page and page_size params"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
+
+func TestStripCommentLinesAllCommentsYieldsEmpty(t *testing.T) {
+	content := "# comment one\n  # comment two\n"
+	if got := stripCommentLines(content); got != "" {
+		t.Fatalf("expected empty string, got %q", got)
+	}
+}
+
+func TestStripCommentLinesKeepsIndentedNonCommentLines(t *testing.T) {
+	content := "feat: thing\n\n  - indented bullet\n# trailing comment\n"
+	got := stripCommentLines(content)
+	want := "feat: thing\n\n  - indented bullet"
+	if got != want {
+		t.Fatalf("expected %q, got %q", want, got)
+	}
+}
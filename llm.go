@@ -0,0 +1,512 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// StreamOptions carries per-request generation parameters that are
+// translated into each provider's native request format.
+type StreamOptions struct {
+	Model       string
+	Temperature *float32
+}
+
+// LLMProvider is implemented by every backend capable of streaming a
+// commit message completion from a list of chat messages. Implementations
+// are responsible for translating ChatMessage/StreamOptions into their own
+// wire format and for decoding whatever streaming protocol they use
+// (SSE, NDJSON, ...) back into plain content tokens.
+type LLMProvider interface {
+	// StreamCompletion starts the completion and returns a channel of
+	// content tokens as they arrive. The channel is closed when the
+	// stream ends, whether that is due to completion or an error; any
+	// error encountered after streaming has started is only logged to
+	// stderr since the channel protocol carries content, not errors.
+	StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error)
+}
+
+// NewProvider builds the LLMProvider selected by config.Provider (defaulting
+// to "deepseek" for backward compatibility with existing config files).
+func NewProvider(config *Config) (LLMProvider, error) {
+	name := config.Provider
+	if name == "" {
+		name = "deepseek"
+	}
+
+	switch strings.ToLower(name) {
+	case "deepseek":
+		if config.DeepSeek.APIKey == "" {
+			return nil, fmt.Errorf("error: No DeepSeek API key found. Please set your API key in the config file")
+		}
+		return &DeepSeekProvider{APIKey: config.DeepSeek.APIKey}, nil
+	case "openai":
+		if config.Providers.OpenAI.APIKey == "" {
+			return nil, fmt.Errorf("error: No OpenAI API key found. Please set providers.openai.api_key in the config file")
+		}
+		return &OpenAIProvider{
+			APIKey:  config.Providers.OpenAI.APIKey,
+			BaseURL: firstNonEmpty(config.Providers.OpenAI.BaseURL, "https://api.openai.com/v1"),
+			Model:   firstNonEmpty(config.Providers.OpenAI.Model, "gpt-4o-mini"),
+		}, nil
+	case "azure":
+		if config.Providers.Azure.APIKey == "" || config.Providers.Azure.Endpoint == "" || config.Providers.Azure.Deployment == "" {
+			return nil, fmt.Errorf("error: Azure OpenAI requires providers.azure.api_key, endpoint and deployment to be set")
+		}
+		return &AzureOpenAIProvider{
+			APIKey:     config.Providers.Azure.APIKey,
+			Endpoint:   strings.TrimRight(config.Providers.Azure.Endpoint, "/"),
+			Deployment: config.Providers.Azure.Deployment,
+			APIVersion: firstNonEmpty(config.Providers.Azure.APIVersion, "2024-02-15-preview"),
+		}, nil
+	case "anthropic":
+		if config.Providers.Anthropic.APIKey == "" {
+			return nil, fmt.Errorf("error: No Anthropic API key found. Please set providers.anthropic.api_key in the config file")
+		}
+		return &AnthropicProvider{
+			APIKey: config.Providers.Anthropic.APIKey,
+			Model:  firstNonEmpty(config.Providers.Anthropic.Model, "claude-3-5-sonnet-latest"),
+		}, nil
+	case "gemini":
+		if config.Providers.Gemini.APIKey == "" {
+			return nil, fmt.Errorf("error: No Gemini API key found. Please set providers.gemini.api_key in the config file")
+		}
+		return &GeminiProvider{
+			APIKey: config.Providers.Gemini.APIKey,
+			Model:  firstNonEmpty(config.Providers.Gemini.Model, "gemini-1.5-flash"),
+		}, nil
+	case "ollama":
+		return &OllamaProvider{
+			BaseURL: firstNonEmpty(config.Providers.Ollama.BaseURL, "http://localhost:11434"),
+			Model:   firstNonEmpty(config.Providers.Ollama.Model, "llama3"),
+		}, nil
+	default:
+		return nil, fmt.Errorf("error: unknown provider %q (expected one of: deepseek, openai, azure, anthropic, gemini, ollama)", name)
+	}
+}
+
+// resolveModelName returns the model that will actually be used for
+// config's selected provider, mirroring the defaults NewProvider applies.
+// Used to key the response cache per-model, not just per-provider.
+func resolveModelName(config *Config) string {
+	name := config.Provider
+	if name == "" {
+		name = "deepseek"
+	}
+
+	switch strings.ToLower(name) {
+	case "deepseek":
+		return "deepseek-chat"
+	case "openai":
+		return firstNonEmpty(config.Providers.OpenAI.Model, "gpt-4o-mini")
+	case "azure":
+		return config.Providers.Azure.Deployment
+	case "anthropic":
+		return firstNonEmpty(config.Providers.Anthropic.Model, "claude-3-5-sonnet-latest")
+	case "gemini":
+		return firstNonEmpty(config.Providers.Gemini.Model, "gemini-1.5-flash")
+	case "ollama":
+		return firstNonEmpty(config.Providers.Ollama.Model, "llama3")
+	default:
+		return name
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// sseTokenStream reads Server-Sent-Events lines off body, extracts the
+// "data: ..." payloads, hands each JSON payload to decode, and forwards
+// whatever content tokens decode returns onto the returned channel. Empty
+// payloads and the literal "data: [DONE]" sentinel used by OpenAI-compatible
+// APIs are skipped; the channel is closed when the stream ends.
+func sseTokenStream(body io.ReadCloser, decode func(payload []byte) []string) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer body.Close()
+
+		scanner := bufio.NewScanner(body)
+		scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if !strings.HasPrefix(line, "data:") {
+				continue
+			}
+			payload := strings.TrimSpace(line[len("data:"):])
+			if payload == "" || payload == "[DONE]" {
+				continue
+			}
+			for _, tok := range decode([]byte(payload)) {
+				out <- tok
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "stream error: %v\n", err)
+		}
+	}()
+	return out
+}
+
+// ---- DeepSeek ----
+
+// DeepSeekProvider talks to DeepSeek's OpenAI-compatible chat completions
+// endpoint.
+type DeepSeekProvider struct {
+	APIKey string
+}
+
+func (p *DeepSeekProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	model := opts.Model
+	if model == "" {
+		model = "deepseek-chat"
+	}
+	resp, err := postJSONStream(ctx, "https://api.deepseek.com/chat/completions", map[string]string{
+		"Authorization": "Bearer " + p.APIKey,
+	}, ChatRequest{Model: model, Messages: messages, Stream: true, Temperature: opts.Temperature})
+	if err != nil {
+		return nil, err
+	}
+	return sseTokenStream(resp.Body, decodeOpenAIChunk), nil
+}
+
+// ---- OpenAI ----
+
+// OpenAIProvider talks to the standard OpenAI chat completions API.
+type OpenAIProvider struct {
+	APIKey  string
+	BaseURL string
+	Model   string
+}
+
+func (p *OpenAIProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	model := firstNonEmpty(opts.Model, p.Model)
+	resp, err := postJSONStream(ctx, p.BaseURL+"/chat/completions", map[string]string{
+		"Authorization": "Bearer " + p.APIKey,
+	}, ChatRequest{Model: model, Messages: messages, Stream: true, Temperature: opts.Temperature})
+	if err != nil {
+		return nil, err
+	}
+	return sseTokenStream(resp.Body, decodeOpenAIChunk), nil
+}
+
+// ---- Azure OpenAI ----
+
+// AzureOpenAIProvider talks to an Azure OpenAI resource, which uses a
+// deployment-scoped URL and an api-key header instead of a Bearer token.
+type AzureOpenAIProvider struct {
+	APIKey     string
+	Endpoint   string
+	Deployment string
+	APIVersion string
+}
+
+func (p *AzureOpenAIProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	url := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s", p.Endpoint, p.Deployment, p.APIVersion)
+	resp, err := postJSONStream(ctx, url, map[string]string{
+		"api-key": p.APIKey,
+	}, ChatRequest{Messages: messages, Stream: true, Temperature: opts.Temperature})
+	if err != nil {
+		return nil, err
+	}
+	return sseTokenStream(resp.Body, decodeOpenAIChunk), nil
+}
+
+func decodeOpenAIChunk(payload []byte) []string {
+	var chunk StreamResponseChunk
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		return nil
+	}
+	var tokens []string
+	for _, choice := range chunk.Choices {
+		if choice.Delta.Content != nil {
+			tokens = append(tokens, *choice.Delta.Content)
+		}
+	}
+	return tokens
+}
+
+// ---- Anthropic ----
+
+// AnthropicProvider talks to the Anthropic Messages API.
+type AnthropicProvider struct {
+	APIKey string
+	Model  string
+}
+
+type anthropicRequest struct {
+	Model       string        `json:"model"`
+	Messages    []ChatMessage `json:"messages"`
+	System      string        `json:"system,omitempty"`
+	MaxTokens   int           `json:"max_tokens"`
+	Stream      bool          `json:"stream"`
+	Temperature *float32      `json:"temperature,omitempty"`
+}
+
+type anthropicEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+func (p *AnthropicProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	model := firstNonEmpty(opts.Model, p.Model)
+
+	// Anthropic takes the system prompt as a top-level field rather than
+	// as a "system" role message.
+	var system string
+	var rest []ChatMessage
+	for _, m := range messages {
+		if m.Role == "system" && system == "" {
+			system = m.Content
+			continue
+		}
+		rest = append(rest, m)
+	}
+
+	resp, err := postJSONStream(ctx, "https://api.anthropic.com/v1/messages", map[string]string{
+		"x-api-key":         p.APIKey,
+		"anthropic-version": "2023-06-01",
+	}, anthropicRequest{
+		Model:       model,
+		Messages:    rest,
+		System:      system,
+		MaxTokens:   1024,
+		Stream:      true,
+		Temperature: opts.Temperature,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sseTokenStream(resp.Body, func(payload []byte) []string {
+		var event anthropicEvent
+		if err := json.Unmarshal(payload, &event); err != nil {
+			return nil
+		}
+		if event.Type != "content_block_delta" || event.Delta.Text == "" {
+			return nil
+		}
+		return []string{event.Delta.Text}
+	}), nil
+}
+
+// ---- Gemini ----
+
+// GeminiProvider talks to Google's Generative Language API, requesting
+// alt=sse so the stream arrives as SSE events like the other providers.
+type GeminiProvider struct {
+	APIKey string
+	Model  string
+}
+
+type geminiRequest struct {
+	Contents          []geminiContent        `json:"contents"`
+	SystemInstruction *geminiContent         `json:"systemInstruction,omitempty"`
+	GenerationConfig  geminiGenerationConfig `json:"generationConfig,omitempty"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text string `json:"text"`
+}
+
+type geminiGenerationConfig struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+type geminiStreamChunk struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+func (p *GeminiProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	model := firstNonEmpty(opts.Model, p.Model)
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:streamGenerateContent?alt=sse&key=%s", model, p.APIKey)
+
+	var system *geminiContent
+	var contents []geminiContent
+	for _, m := range messages {
+		if m.Role == "system" && system == nil {
+			system = &geminiContent{Parts: []geminiPart{{Text: m.Content}}}
+			continue
+		}
+		role := "user"
+		if m.Role == "assistant" {
+			role = "model"
+		}
+		contents = append(contents, geminiContent{Role: role, Parts: []geminiPart{{Text: m.Content}}})
+	}
+
+	resp, err := postJSONStream(ctx, url, nil, geminiRequest{
+		Contents:          contents,
+		SystemInstruction: system,
+		GenerationConfig:  geminiGenerationConfig{Temperature: opts.Temperature},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sseTokenStream(resp.Body, func(payload []byte) []string {
+		var chunk geminiStreamChunk
+		if err := json.Unmarshal(payload, &chunk); err != nil {
+			return nil
+		}
+		var tokens []string
+		for _, c := range chunk.Candidates {
+			for _, part := range c.Content.Parts {
+				if part.Text != "" {
+					tokens = append(tokens, part.Text)
+				}
+			}
+		}
+		return tokens
+	}), nil
+}
+
+// ---- Ollama ----
+
+// OllamaProvider talks to a local Ollama daemon, which streams
+// newline-delimited JSON objects (one per line) rather than SSE.
+type OllamaProvider struct {
+	BaseURL string
+	Model   string
+}
+
+type ollamaRequest struct {
+	Model    string        `json:"model"`
+	Messages []ChatMessage `json:"messages"`
+	Stream   bool          `json:"stream"`
+	Options  ollamaOptions `json:"options,omitempty"`
+}
+
+type ollamaOptions struct {
+	Temperature *float32 `json:"temperature,omitempty"`
+}
+
+type ollamaChunk struct {
+	Message ChatMessage `json:"message"`
+	Done    bool        `json:"done"`
+}
+
+func (p *OllamaProvider) StreamCompletion(ctx context.Context, messages []ChatMessage, opts StreamOptions) (<-chan string, error) {
+	model := firstNonEmpty(opts.Model, p.Model)
+
+	jsonData, err := json.Marshal(ollamaRequest{
+		Model:    model,
+		Messages: messages,
+		Stream:   true,
+		Options:  ollamaOptions{Temperature: opts.Temperature},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimRight(p.BaseURL, "/")+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API failed: %s", string(body))
+	}
+
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				continue
+			}
+			var chunk ollamaChunk
+			if err := json.Unmarshal([]byte(line), &chunk); err != nil {
+				continue
+			}
+			if chunk.Message.Content != "" {
+				out <- chunk.Message.Content
+			}
+			if chunk.Done {
+				break
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			fmt.Fprintf(os.Stderr, "stream error: %v\n", err)
+		}
+	}()
+	return out, nil
+}
+
+// collectCompletion drains a provider's StreamCompletion into a single
+// string instead of streaming it to the terminal. Used where the output
+// isn't echoed as it arrives: per-file diff summaries and the commit
+// message generated in --hook mode.
+func collectCompletion(provider LLMProvider, messages []ChatMessage, temperature *float32) (string, error) {
+	tokens, err := provider.StreamCompletion(context.Background(), messages, StreamOptions{Temperature: temperature})
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for tok := range tokens {
+		b.WriteString(tok)
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// postJSONStream issues a POST request with the given JSON body and extra
+// headers, returning the raw response so callers can decode its streaming
+// body in whatever format the provider uses.
+func postJSONStream(ctx context.Context, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		return nil, fmt.Errorf("API failed: %s", string(respBody))
+	}
+	return resp, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewProviderMissingAPIKeys(t *testing.T) {
+	cases := []struct {
+		name    string
+		config  *Config
+		wantSub string
+	}{
+		{"deepseek", &Config{Provider: "deepseek"}, "DeepSeek API key"},
+		{"openai", &Config{Provider: "openai"}, "providers.openai.api_key"},
+		{"azure", &Config{Provider: "azure"}, "providers.azure"},
+		{"anthropic", &Config{Provider: "anthropic"}, "providers.anthropic.api_key"},
+		{"gemini", &Config{Provider: "gemini"}, "providers.gemini.api_key"},
+		{"unknown", &Config{Provider: "bogus"}, "unknown provider"},
+	}
+	for _, c := range cases {
+		_, err := NewProvider(c.config)
+		if err == nil {
+			t.Errorf("%s: expected an error for missing config, got nil", c.name)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.wantSub) {
+			t.Errorf("%s: expected error to mention %q, got: %v", c.name, c.wantSub, err)
+		}
+	}
+}
+
+func TestNewProviderDefaultsToDeepSeek(t *testing.T) {
+	_, err := NewProvider(&Config{})
+	if err == nil || !strings.Contains(err.Error(), "DeepSeek API key") {
+		t.Fatalf("expected an empty Provider to default to deepseek, got: %v", err)
+	}
+}
+
+func TestNewProviderOllamaNeedsNoAPIKey(t *testing.T) {
+	provider, err := NewProvider(&Config{Provider: "ollama"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	p, ok := provider.(*OllamaProvider)
+	if !ok {
+		t.Fatalf("expected *OllamaProvider, got %T", provider)
+	}
+	if p.BaseURL != "http://localhost:11434" || p.Model != "llama3" {
+		t.Fatalf("unexpected defaults: %+v", p)
+	}
+}
+
+func TestResolveModelNameDefaults(t *testing.T) {
+	cases := []struct {
+		config *Config
+		want   string
+	}{
+		{&Config{}, "deepseek-chat"},
+		{&Config{Provider: "deepseek"}, "deepseek-chat"},
+		{&Config{Provider: "openai"}, "gpt-4o-mini"},
+		{&Config{Provider: "openai", Providers: ProvidersConfig{OpenAI: OpenAIConfig{Model: "gpt-4o"}}}, "gpt-4o"},
+		{&Config{Provider: "anthropic"}, "claude-3-5-sonnet-latest"},
+		{&Config{Provider: "gemini"}, "gemini-1.5-flash"},
+		{&Config{Provider: "ollama"}, "llama3"},
+		{&Config{Provider: "azure", Providers: ProvidersConfig{Azure: AzureConfig{Deployment: "my-deployment"}}}, "my-deployment"},
+		{&Config{Provider: "bogus"}, "bogus"},
+	}
+	for _, c := range cases {
+		if got := resolveModelName(c.config); got != c.want {
+			t.Errorf("config %+v: expected %q, got %q", c.config, c.want, got)
+		}
+	}
+}
+
+func TestFirstNonEmpty(t *testing.T) {
+	if got := firstNonEmpty("", "", "fallback"); got != "fallback" {
+		t.Fatalf("expected fallback, got %q", got)
+	}
+	if got := firstNonEmpty("first", "second"); got != "first" {
+		t.Fatalf("expected first non-empty value, got %q", got)
+	}
+	if got := firstNonEmpty("", ""); got != "" {
+		t.Fatalf("expected empty string when all values are empty, got %q", got)
+	}
+}
+
+func TestDecodeOpenAIChunk(t *testing.T) {
+	payload := []byte(`{"choices":[{"delta":{"content":"hello"}}]}`)
+	tokens := decodeOpenAIChunk(payload)
+	if len(tokens) != 1 || tokens[0] != "hello" {
+		t.Fatalf("expected [\"hello\"], got %v", tokens)
+	}
+}
+
+func TestDecodeOpenAIChunkNoContent(t *testing.T) {
+	payload := []byte(`{"choices":[{"delta":{}}]}`)
+	if tokens := decodeOpenAIChunk(payload); tokens != nil {
+		t.Fatalf("expected no tokens for a content-less delta, got %v", tokens)
+	}
+}
+
+func TestDecodeOpenAIChunkInvalidJSON(t *testing.T) {
+	if tokens := decodeOpenAIChunk([]byte("not json")); tokens != nil {
+		t.Fatalf("expected nil tokens for invalid JSON, got %v", tokens)
+	}
+}
+
+// fakeSSEBody lets sseTokenStream be tested without a real HTTP response.
+type fakeSSEBody struct {
+	*strings.Reader
+}
+
+func (fakeSSEBody) Close() error { return nil }
+
+func TestSSETokenStreamSkipsDoneAndEmptyPayloads(t *testing.T) {
+	raw := "data: {\"choices\":[{\"delta\":{\"content\":\"foo\"}}]}\n" +
+		"data: \n" +
+		"data: [DONE]\n" +
+		"data: {\"choices\":[{\"delta\":{\"content\":\"bar\"}}]}\n"
+
+	out := sseTokenStream(fakeSSEBody{strings.NewReader(raw)}, decodeOpenAIChunk)
+
+	var got []string
+	for tok := range out {
+		got = append(got, tok)
+	}
+	if len(got) != 2 || got[0] != "foo" || got[1] != "bar" {
+		t.Fatalf("expected [foo bar], got %v", got)
+	}
+}
@@ -1,12 +1,8 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -19,9 +15,15 @@ import (
 	"github.com/spf13/viper"
 )
 
-// Config structure for DeepSeek API
+// Config structure for git-aicommit. Provider selects which backend in
+// Providers (or the legacy top-level DeepSeek block) to use.
 type Config struct {
-	DeepSeek DeepSeekConfig `mapstructure:"deepseek"`
+	Provider      string          `mapstructure:"provider"`
+	DeepSeek      DeepSeekConfig  `mapstructure:"deepseek"`
+	Providers     ProvidersConfig `mapstructure:"providers"`
+	Conventional  bool            `mapstructure:"conventional"`
+	Scopes        []string        `mapstructure:"scopes"`
+	MaxDiffTokens int             `mapstructure:"max_diff_tokens"`
 }
 
 type DeepSeekConfig struct {
@@ -30,6 +32,45 @@ type DeepSeekConfig struct {
 	Prompt      *string  `mapstructure:"prompt"`
 }
 
+// ProvidersConfig holds per-provider settings under `[providers.*]` in
+// config.toml. DeepSeek keeps its own top-level `[deepseek]` block for
+// backward compatibility with existing config files.
+type ProvidersConfig struct {
+	OpenAI    OpenAIConfig    `mapstructure:"openai"`
+	Azure     AzureConfig     `mapstructure:"azure"`
+	Anthropic AnthropicConfig `mapstructure:"anthropic"`
+	Gemini    GeminiConfig    `mapstructure:"gemini"`
+	Ollama    OllamaConfig    `mapstructure:"ollama"`
+}
+
+type OpenAIConfig struct {
+	APIKey  string `mapstructure:"api_key"`
+	BaseURL string `mapstructure:"base_url"`
+	Model   string `mapstructure:"model"`
+}
+
+type AzureConfig struct {
+	APIKey     string `mapstructure:"api_key"`
+	Endpoint   string `mapstructure:"endpoint"`
+	Deployment string `mapstructure:"deployment"`
+	APIVersion string `mapstructure:"api_version"`
+}
+
+type AnthropicConfig struct {
+	APIKey string `mapstructure:"api_key"`
+	Model  string `mapstructure:"model"`
+}
+
+type GeminiConfig struct {
+	APIKey string `mapstructure:"api_key"`
+	Model  string `mapstructure:"model"`
+}
+
+type OllamaConfig struct {
+	BaseURL string `mapstructure:"base_url"`
+	Model   string `mapstructure:"model"`
+}
+
 // ChatMessage represents a message in the chat completion request
 type ChatMessage struct {
 	Role    string `json:"role"`
@@ -58,8 +99,22 @@ type DeltaMessage struct {
 }
 
 var (
-	applyFlag bool
-	rootCmd   = &cobra.Command{
+	applyFlag              bool
+	providerFlag           string
+	yesFlag                bool
+	noInteractiveFlag      bool
+	conventionalFlag       bool
+	maxDiffBytesFlag       int
+	summarizeThresholdFlag int
+	hookFlag               string
+	unstagedFlag           bool
+	allFlag                bool
+	amendFlag              bool
+	rangeFlag              string
+	outputFlag             string
+	noCacheFlag            bool
+	cacheOnlyFlag          bool
+	rootCmd                = &cobra.Command{
 		Use:               "ai-commit",
 		Short:             "Create an AI-generated commit",
 		Long:              `Generate commit messages using AI and optionally create commits with those messages.`,
@@ -70,6 +125,20 @@ var (
 
 func init() {
 	rootCmd.Flags().BoolVarP(&applyFlag, "apply", "a", false, "Apply the AI-generated message to the new commit")
+	rootCmd.Flags().StringVar(&providerFlag, "provider", "", "LLM provider to use (deepseek, openai, azure, anthropic, gemini, ollama)")
+	rootCmd.Flags().BoolVarP(&yesFlag, "yes", "y", false, "With --apply, skip the interactive review prompt and commit the generated message as-is")
+	rootCmd.Flags().BoolVar(&noInteractiveFlag, "no-interactive", false, "Disable the interactive review prompt (same effect as --yes)")
+	rootCmd.Flags().BoolVar(&conventionalFlag, "conventional", false, "Generate a Conventional Commits formatted message and validate it locally")
+	rootCmd.Flags().IntVar(&maxDiffBytesFlag, "max-diff-bytes", 0, "Byte budget for the diff sent to the model (default ~24000, see max_diff_tokens)")
+	rootCmd.Flags().IntVar(&summarizeThresholdFlag, "summarize-threshold", 0, "Diff size in bytes above which per-file summaries replace the full diff (default 16000)")
+	rootCmd.Flags().StringVar(&hookFlag, "hook", "", "Internal: run as a prepare-commit-msg hook, writing the generated message into this commit-msg file")
+	rootCmd.Flags().BoolVar(&unstagedFlag, "unstaged", false, "Generate from unstaged changes instead of the staged diff")
+	rootCmd.Flags().BoolVar(&allFlag, "all", false, "Stage tracked file changes first (like \"git commit -a\"), then generate from the staged diff")
+	rootCmd.Flags().BoolVar(&amendFlag, "amend", false, "Generate from HEAD~1..HEAD and apply by amending the last commit")
+	rootCmd.Flags().StringVar(&rangeFlag, "range", "", "Generate a PR description from a commit range (rev1..rev2) instead of the working tree; requires --output pr")
+	rootCmd.Flags().StringVar(&outputFlag, "output", "commit", "What to generate: \"commit\" (default) or \"pr\" (requires --range)")
+	rootCmd.Flags().BoolVar(&noCacheFlag, "no-cache", false, "Don't read or write the local response cache")
+	rootCmd.Flags().BoolVar(&cacheOnlyFlag, "cache-only", false, "Fail instead of calling the provider if no cached response exists")
 
 	// Disable completion command
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
@@ -89,31 +158,109 @@ func runCommit(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if config.DeepSeek.APIKey == "" {
-		return fmt.Errorf("error: No DeepSeek API key found. Please set your API key in the config file")
+	if providerFlag != "" {
+		config.Provider = providerFlag
+	}
+	if conventionalFlag {
+		config.Conventional = true
+	}
+
+	if hookFlag != "" {
+		return runHookMode(config, hookFlag, args)
 	}
 
-	// Get staged changes
-	changes, err := getStagedChanges()
+	provider, err := NewProvider(config)
+	if err != nil {
+		return err
+	}
+
+	mode, err := resolveDiffMode(unstagedFlag, allFlag, amendFlag, rangeFlag)
+	if err != nil {
+		return err
+	}
+	if mode == "unstaged" && applyFlag {
+		return fmt.Errorf("--unstaged --apply would commit the staged index, not the unstaged diff that was summarized; stage your changes first (or use --all) and rerun with --apply")
+	}
+
+	if outputFlag == "pr" {
+		if mode != "range" {
+			return fmt.Errorf("--output pr requires --range <rev1>..<rev2>")
+		}
+		return runPRMode(config, provider, rangeFlag)
+	}
+	if mode == "range" {
+		return fmt.Errorf("--range generates a PR description; pass --output pr")
+	}
+
+	if mode == "all" {
+		if err := stageTrackedChanges(); err != nil {
+			return fmt.Errorf("failed to stage tracked changes: %w", err)
+		}
+	}
+	baseArgs := gitDiffBaseArgs(mode, rangeFlag)
+
+	// Get the diff, summarizing per-file if it's too large to send in full.
+	maxDiffBytes := effectiveMaxDiffBytes(maxDiffBytesFlag, config.MaxDiffTokens)
+	summarizeThreshold := effectiveSummarizeThreshold(summarizeThresholdFlag)
+	changes, err := prepareDiffContext(provider, config.DeepSeek.Temperature, maxDiffBytes, summarizeThreshold, baseArgs)
 	if err != nil {
 		return fmt.Errorf("failed to get staged changes: %w", err)
 	}
 
 	// Build prompt messages
-	messages := buildPromptMessages(changes, config.DeepSeek.Prompt)
+	var messages []ChatMessage
+	if config.Conventional {
+		messages = buildPromptMessages(changes, ptrTo(buildConventionalPrompt(config.Scopes)))
+	} else {
+		messages = buildPromptMessages(changes, config.DeepSeek.Prompt)
+	}
 
 	// Print banner
 	printBanner("AI Suggested Commit Message")
 
-	// Stream commit message
-	fullMessage, err := streamCommitMessage(config.DeepSeek.APIKey, messages, config.DeepSeek.Temperature)
+	// Stream commit message, replaying a cached response if one exists
+	providerName := config.Provider
+	if providerName == "" {
+		providerName = "deepseek"
+	}
+	fullMessage, err := runStreamCached(provider, messages, config.DeepSeek.Temperature, providerName, resolveModelName(config), noCacheFlag, cacheOnlyFlag)
 	if err != nil {
 		return fmt.Errorf("failed to generate commit message: %w", err)
 	}
 
+	if config.Conventional {
+		fullMessage, err = enforceConventional(provider, messages, config.DeepSeek.Temperature, fullMessage, config.Scopes)
+		if err != nil {
+			return fmt.Errorf("failed to generate a valid Conventional Commits message: %w", err)
+		}
+	}
+
 	// Apply commit if requested
 	if applyFlag {
-		commitID, err := createCommit(strings.TrimSpace(fullMessage))
+		finalMessage := strings.TrimSpace(fullMessage)
+
+		interactive := !yesFlag && !noInteractiveFlag && isTerminal(os.Stdout)
+		if interactive {
+			reviewed, proceed, err := reviewLoop(provider, messages, config.DeepSeek.Temperature, finalMessage)
+			if err != nil {
+				return fmt.Errorf("failed during interactive review: %w", err)
+			}
+			if !proceed {
+				fmt.Println("Aborted: no commit was created.")
+				return nil
+			}
+			finalMessage = reviewed
+		}
+
+		if mode == "amend" {
+			if err := amendCommit(finalMessage); err != nil {
+				return fmt.Errorf("failed to amend commit: %w", err)
+			}
+			printBanner("✅ Commit Amended")
+			return nil
+		}
+
+		commitID, err := createCommit(finalMessage)
 		if err != nil {
 			return fmt.Errorf("failed to create commit: %w", err)
 		}
@@ -177,6 +324,16 @@ func createDefaultConfig() error {
 # Git-Aicommit Configuration File
 # This file contains configuration settings for the git-aicommit CLI tool
 
+# Which provider to use by default. Overridable with --provider.
+# One of: deepseek, openai, azure, anthropic, gemini, ollama
+provider = "deepseek"
+
+# Require Conventional Commits formatted messages. Overridable with --conventional.
+conventional = false
+
+# Scopes suggested to the AI and enforced during local validation when set.
+# scopes = ["api", "cli"]
+
 [deepseek]
 # DeepSeek API key for AI-powered commit message generation
 # Get your API key from: https://platform.deepseek.com/
@@ -208,24 +365,34 @@ Improve error handling in user authentication
 - Handle timeout errors gracefully
 - Refactor error propagation logic for clarity
 """
-`
 
-	return os.WriteFile(configPath, []byte(configContent), 0644)
-}
+# Settings for the other supported providers. Only the block for the
+# provider you select above needs to be filled in.
+[providers.openai]
+api_key = ""
+# base_url = "https://api.openai.com/v1"
+# model = "gpt-4o-mini"
 
-func getStagedChanges() (string, error) {
-	// Use git command to get staged changes
-	cmd := exec.Command("git", "diff", "--cached")
-	output, err := cmd.Output()
-	if err != nil {
-		return "", err
-	}
+[providers.azure]
+api_key = ""
+endpoint = ""
+deployment = ""
+# api_version = "2024-02-15-preview"
 
-	if len(output) == 0 {
-		return "", fmt.Errorf("no staged changes found")
-	}
+[providers.anthropic]
+api_key = ""
+# model = "claude-3-5-sonnet-latest"
 
-	return string(output), nil
+[providers.gemini]
+api_key = ""
+# model = "gemini-1.5-flash"
+
+[providers.ollama]
+# base_url = "http://localhost:11434"
+# model = "llama3"
+`
+
+	return os.WriteFile(configPath, []byte(configContent), 0644)
 }
 
 func buildPromptMessages(changes string, promptOpt *string) []ChatMessage {
@@ -286,71 +453,27 @@ func printBanner(title string) {
 	fmt.Println(bannerLine)
 }
 
-func streamCommitMessage(apiKey string, messages []ChatMessage, temperature *float32) (string, error) {
-	requestBody := ChatRequest{
-		Model:       "deepseek-chat",
-		Messages:    messages,
-		Stream:      true,
-		Temperature: temperature,
-	}
-
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return "", err
-	}
-
-	req, err := http.NewRequest("POST", "https://api.deepseek.com/chat/completions", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return "", err
-	}
-
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+apiKey)
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// runStream drives a provider's StreamCompletion, printing each completed
+// line prefixed with "| " as it arrives (so the user sees progress as the
+// model generates), and returns the full accumulated message.
+func runStream(provider LLMProvider, messages []ChatMessage, temperature *float32) (string, error) {
+	tokens, err := provider.StreamCompletion(context.Background(), messages, StreamOptions{Temperature: temperature})
 	if err != nil {
 		return "", err
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("API failed: %s", string(body))
-	}
 
 	var fullMessage strings.Builder
 	var currentLine strings.Builder
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		if strings.HasPrefix(line, "data:") && line != "data: [DONE]" {
-			jsonStr := strings.TrimSpace(line[5:])
-			if jsonStr == "" {
-				continue
-			}
-
-			var chunk StreamResponseChunk
-			if err := json.Unmarshal([]byte(jsonStr), &chunk); err != nil {
-				continue
-			}
-
-			for _, choice := range chunk.Choices {
-				if choice.Delta.Content != nil {
-					content := *choice.Delta.Content
-					for _, ch := range content {
-						currentLine.WriteRune(ch)
-						if ch == '\n' {
-							line := currentLine.String()
-							fmt.Printf("| %s", strings.TrimSuffix(line, "\n"))
-							fmt.Println()
-							fullMessage.WriteString(line)
-							currentLine.Reset()
-						}
-					}
-				}
+	for content := range tokens {
+		for _, ch := range content {
+			currentLine.WriteRune(ch)
+			if ch == '\n' {
+				line := currentLine.String()
+				fmt.Printf("| %s", strings.TrimSuffix(line, "\n"))
+				fmt.Println()
+				fullMessage.WriteString(line)
+				currentLine.Reset()
 			}
 		}
 	}
@@ -362,10 +485,6 @@ func streamCommitMessage(apiKey string, messages []ChatMessage, temperature *flo
 		fullMessage.WriteString(line)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return "", err
-	}
-
 	return fullMessage.String(), nil
 }
 
@@ -0,0 +1,10 @@
+//go:build !tiktoken
+
+package main
+
+// estimateTokens approximates the number of LLM tokens in s using the
+// common bytes/4 heuristic. Build with the "tiktoken" tag for an exact
+// count via tiktoken-go instead.
+func estimateTokens(s string) int {
+	return len(s) / 4
+}
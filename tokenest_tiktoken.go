@@ -0,0 +1,16 @@
+//go:build tiktoken
+
+package main
+
+import "github.com/pkoukk/tiktoken-go"
+
+// estimateTokens counts tokens exactly using tiktoken-go's cl100k_base
+// encoding, falling back to the bytes/4 heuristic if the encoder can't be
+// loaded (e.g. no network access to fetch its vocabulary file).
+func estimateTokens(s string) int {
+	enc, err := tiktoken.GetEncoding("cl100k_base")
+	if err != nil {
+		return len(s) / 4
+	}
+	return len(enc.Encode(s, nil, nil))
+}